@@ -0,0 +1,194 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"time"
+
+	"github.com/kubernetes-incubator/cri-tools/pkg/framework"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	kubeletutil "k8s.io/kubernetes/pkg/kubelet/util"
+
+	runtimeapiv1 "k8s.io/cri-api/pkg/apis/runtime/v1"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/api/v1alpha1/runtime"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// v1DialTimeout bounds how long negotiateImageClient waits for the
+// runtime.v1 Version RPC to answer before falling back to v1alpha1. A
+// v1alpha1-only runtime won't serve this RPC at all, so this only needs to
+// cover the round trip, not a slow pull or similar.
+const v1DialTimeout = 5 * time.Second
+
+// imageServiceClient is the subset of the CRI ImageService that the
+// conformance suite exercises. Runtimes in the wild expose either the
+// long-standing v1alpha1/v1alpha2 API or the newer runtime.v1 API; both are
+// adapted to this interface so the tests in this package don't need to know
+// which one they are talking to. internalapi.ImageManagerService already
+// satisfies this interface, so the existing v1alpha1 path needs no adapter
+// at all.
+type imageServiceClient interface {
+	PullImage(image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig) (string, error)
+	RemoveImage(image *runtimeapi.ImageSpec) error
+	ImageStatus(image *runtimeapi.ImageSpec) (*runtimeapi.Image, error)
+	ListImages(filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error)
+}
+
+// negotiateImageClient picks the newest image service API the runtime
+// behind f's CRI socket supports: it dials the same endpoint a second time
+// and probes the runtime.v1 Version RPC, falling back to the v1alpha1
+// client the framework already dialed if that probe fails or times out.
+// This mirrors how the OpenKruise daemon's criruntime factory negotiates a
+// CRI version at dial time.
+func negotiateImageClient(f *framework.TestFramework) imageServiceClient {
+	if v1 := dialV1ImageClient(f); v1 != nil {
+		return v1
+	}
+	framework.Logf("runtime.v1 Version RPC not served by %s, falling back to v1alpha1", f.TestContext.ImageServiceAddr)
+	return f.CRIClient.CRIImageClient
+}
+
+// dialV1ImageClient dials f's CRI image service endpoint a second time and
+// probes it for the runtime.v1 Version RPC, returning an imageServiceClient
+// backed by the v1 ImageServiceClient if the probe succeeds. It returns nil
+// if the endpoint can't be dialed or only understands the older
+// v1alpha1/v1alpha2 API, so callers fall back to that client.
+func dialV1ImageClient(f *framework.TestFramework) imageServiceClient {
+	addr, dialer, err := kubeletutil.GetAddressAndDialer(f.TestContext.ImageServiceAddr)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), v1DialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithContextDialer(dialer), grpc.WithBlock())
+	if err != nil {
+		return nil
+	}
+
+	v1RuntimeClient := runtimeapiv1.NewRuntimeServiceClient(conn)
+	if _, err := v1RuntimeClient.Version(ctx, &runtimeapiv1.VersionRequest{}); err != nil {
+		conn.Close()
+		return nil
+	}
+
+	return &v1ImageClient{client: runtimeapiv1.NewImageServiceClient(conn)}
+}
+
+// v1ImageClient adapts a runtime.v1 ImageServiceClient to imageServiceClient,
+// translating between the v1alpha1 wire types the rest of this package uses
+// and the v1 types the runtime actually understands.
+type v1ImageClient struct {
+	client runtimeapiv1.ImageServiceClient
+}
+
+func (v *v1ImageClient) PullImage(image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig) (string, error) {
+	resp, err := v.client.PullImage(context.Background(), &runtimeapiv1.PullImageRequest{
+		Image: toV1ImageSpec(image),
+		Auth:  toV1AuthConfig(auth),
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.ImageRef, nil
+}
+
+func (v *v1ImageClient) RemoveImage(image *runtimeapi.ImageSpec) error {
+	_, err := v.client.RemoveImage(context.Background(), &runtimeapiv1.RemoveImageRequest{
+		Image: toV1ImageSpec(image),
+	})
+	return err
+}
+
+func (v *v1ImageClient) ImageStatus(image *runtimeapi.ImageSpec) (*runtimeapi.Image, error) {
+	resp, err := v.client.ImageStatus(context.Background(), &runtimeapiv1.ImageStatusRequest{
+		Image: toV1ImageSpec(image),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromV1Image(resp.Image), nil
+}
+
+func (v *v1ImageClient) ListImages(filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error) {
+	var v1Filter *runtimeapiv1.ImageFilter
+	if filter != nil && filter.Image != nil {
+		v1Filter = &runtimeapiv1.ImageFilter{Image: toV1ImageSpec(filter.Image)}
+	}
+
+	resp, err := v.client.ListImages(context.Background(), &runtimeapiv1.ListImagesRequest{Filter: v1Filter})
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*runtimeapi.Image, 0, len(resp.Images))
+	for _, image := range resp.Images {
+		images = append(images, fromV1Image(image))
+	}
+	return images, nil
+}
+
+func toV1ImageSpec(image *runtimeapi.ImageSpec) *runtimeapiv1.ImageSpec {
+	if image == nil {
+		return nil
+	}
+	return &runtimeapiv1.ImageSpec{Image: image.Image}
+}
+
+func toV1AuthConfig(auth *runtimeapi.AuthConfig) *runtimeapiv1.AuthConfig {
+	if auth == nil {
+		return nil
+	}
+	return &runtimeapiv1.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		Auth:          auth.Auth,
+		ServerAddress: auth.ServerAddress,
+		IdentityToken: auth.IdentityToken,
+		RegistryToken: auth.RegistryToken,
+	}
+}
+
+func fromV1Image(image *runtimeapiv1.Image) *runtimeapi.Image {
+	if image == nil {
+		return nil
+	}
+	return &runtimeapi.Image{
+		Id:          image.Id,
+		RepoTags:    image.RepoTags,
+		RepoDigests: image.RepoDigests,
+		Size_:       image.Size_,
+	}
+}
+
+// imageClientFixture wires up the negotiated imageServiceClient for a
+// KubeDescribe block: every Image Manager spec file was repeating
+// "var c imageServiceClient; BeforeEach(func() { c = negotiateImageClient(f) })"
+// verbatim, so that wiring now lives here once and each file just calls
+// getClient() from inside its It()s.
+func imageClientFixture(f *framework.TestFramework) func() imageServiceClient {
+	var c imageServiceClient
+	BeforeEach(func() {
+		c = negotiateImageClient(f)
+	})
+	return func() imageServiceClient {
+		return c
+	}
+}
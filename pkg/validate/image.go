@@ -20,7 +20,6 @@ import (
 	"strings"
 
 	"github.com/kubernetes-incubator/cri-tools/pkg/framework"
-	internalapi "k8s.io/kubernetes/pkg/kubelet/api"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/api/v1alpha1/runtime"
 
 	. "github.com/onsi/ginkgo"
@@ -41,25 +40,29 @@ var (
 var _ = framework.KubeDescribe("Image Manager", func() {
 	f := framework.NewDefaultCRIFramework()
 
-	var c internalapi.ImageManagerService
-
-	BeforeEach(func() {
-		c = f.CRIClient.CRIImageClient
-	})
+	getClient := imageClientFixture(f)
 
 	It("public image with tag should be pulled and removed [Conformance]", func() {
+		c := getClient()
+
 		testPullPublicImage(c, testImageRef)
 	})
 
 	It("public image without tag should be pulled and removed [Conformance]", func() {
+		c := getClient()
+
 		testPullPublicImage(c, testImageName)
 	})
 
 	It("public image with digest should be pulled and removed [Conformance]", func() {
+		c := getClient()
+
 		testPullPublicImage(c, busyboxDigestRef)
 	})
 
 	It("image status get image fields should not be empty [Conformance]", func() {
+		c := getClient()
+
 		pullPublicImage(c, testImageRef)
 
 		defer removeImage(c, testImageRef)
@@ -71,6 +74,8 @@ var _ = framework.KubeDescribe("Image Manager", func() {
 	})
 
 	It("listImage should get exactly 3 image in the result list [Conformance]", func() {
+		c := getClient()
+
 		// different tags refer to different images
 		testImageList := []string{
 			"busybox:1-uclibc",
@@ -103,6 +108,8 @@ var _ = framework.KubeDescribe("Image Manager", func() {
 	})
 
 	It("listImage should get exactly 3 repoTags in the result image [Conformance]", func() {
+		c := getClient()
+
 		// different tags refer to the same image
 		testImageList := []string{
 			"busybox:1.26.2-uclibc",
@@ -138,7 +145,7 @@ var _ = framework.KubeDescribe("Image Manager", func() {
 })
 
 // testRemoveImage removes the image name imageName and check if it successes.
-func testRemoveImage(c internalapi.ImageManagerService, imageName string) {
+func testRemoveImage(c imageServiceClient, imageName string) {
 	By("Remove image : " + imageName)
 	removeImage(c, imageName)
 
@@ -148,7 +155,7 @@ func testRemoveImage(c internalapi.ImageManagerService, imageName string) {
 }
 
 // testPullPublicImage pulls the image named imageName, make sure it success and remove the image.
-func testPullPublicImage(c internalapi.ImageManagerService, imageName string) {
+func testPullPublicImage(c imageServiceClient, imageName string) {
 	if !strings.Contains(imageName, ":") {
 		imageName = imageName + ":latest"
 	}
@@ -162,7 +169,7 @@ func testPullPublicImage(c internalapi.ImageManagerService, imageName string) {
 }
 
 // imageStatus gets the status of the image named imageName.
-func imageStatus(c internalapi.ImageManagerService, imageName string) *runtimeapi.Image {
+func imageStatus(c imageServiceClient, imageName string) *runtimeapi.Image {
 	By("Get image status")
 	imageSpec := &runtimeapi.ImageSpec{
 		Image: imageName,
@@ -173,21 +180,21 @@ func imageStatus(c internalapi.ImageManagerService, imageName string) *runtimeap
 }
 
 // pullImageList pulls the images listed in the imageList.
-func pullImageList(c internalapi.ImageManagerService, imageList []string) {
+func pullImageList(c imageServiceClient, imageList []string) {
 	for _, imageName := range imageList {
 		pullPublicImage(c, imageName)
 	}
 }
 
 // removeImageList removes the images listed in the imageList.
-func removeImageList(c internalapi.ImageManagerService, imageList []string) {
+func removeImageList(c imageServiceClient, imageList []string) {
 	for _, imageName := range imageList {
 		removeImage(c, imageName)
 	}
 }
 
 // pullPublicImage pulls the public image named imageName.
-func pullPublicImage(c internalapi.ImageManagerService, imageName string) {
+func pullPublicImage(c imageServiceClient, imageName string) {
 	if !strings.Contains(imageName, ":") {
 		imageName = imageName + ":latest"
 		framework.Logf("Use latest as default image tag.")
@@ -202,7 +209,7 @@ func pullPublicImage(c internalapi.ImageManagerService, imageName string) {
 }
 
 // removeImage removes the image named imagesName.
-func removeImage(c internalapi.ImageManagerService, imageName string) {
+func removeImage(c imageServiceClient, imageName string) {
 	By("Remove image : " + imageName)
 	imageSpec := &runtimeapi.ImageSpec{
 		Image: imageName,
@@ -212,7 +219,7 @@ func removeImage(c internalapi.ImageManagerService, imageName string) {
 }
 
 // listImageForImageName lists the images named imageName.
-func listImageForImageName(c internalapi.ImageManagerService, imageName string) []*runtimeapi.Image {
+func listImageForImageName(c imageServiceClient, imageName string) []*runtimeapi.Image {
 	By("Get image list for imageName : " + imageName)
 	filter := &runtimeapi.ImageFilter{
 		Image: &runtimeapi.ImageSpec{Image: imageName},
@@ -222,7 +229,7 @@ func listImageForImageName(c internalapi.ImageManagerService, imageName string)
 }
 
 // listImage list the image filtered by the image filter.
-func listImage(c internalapi.ImageManagerService, filter *runtimeapi.ImageFilter) []*runtimeapi.Image {
+func listImage(c imageServiceClient, filter *runtimeapi.ImageFilter) []*runtimeapi.Image {
 	images, err := c.ListImages(filter)
 	framework.ExpectNoError(err, "Failed to get image list: %v", err)
 	return images
@@ -0,0 +1,237 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/kubernetes-incubator/cri-tools/pkg/framework"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/api/v1alpha1/runtime"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var (
+	// testPrivateRegistry is the host of the registry used by the
+	// authenticated pull conformance tests. Override with
+	// CRI_TOOLS_REGISTRY_HOST to point at a registry reachable from the
+	// test environment.
+	testPrivateRegistry = func() string {
+		if host := os.Getenv("CRI_TOOLS_REGISTRY_HOST"); host != "" {
+			return host
+		}
+		return "registry.example.com"
+	}()
+
+	// testPrivateImageRef is the image pulled from testPrivateRegistry to
+	// exercise authenticated pulls.
+	testPrivateImageRef = testPrivateRegistry + "/conformance/busybox:1.26.2"
+)
+
+// ImageCredentialProvider resolves the AuthConfig to use when pulling
+// images from a given registry host.
+type ImageCredentialProvider interface {
+	// Resolve returns the AuthConfig to use when pulling from
+	// registryHost, or nil if no credentials are configured for it.
+	Resolve(registryHost string) (*runtimeapi.AuthConfig, error)
+}
+
+// staticCredentialProvider always resolves to the same AuthConfig,
+// regardless of the registry host being pulled from.
+type staticCredentialProvider struct {
+	auth *runtimeapi.AuthConfig
+}
+
+// NewUserPassCredentialProvider builds an ImageCredentialProvider backed by
+// a static username/password pair.
+func NewUserPassCredentialProvider(username, password string) ImageCredentialProvider {
+	return &staticCredentialProvider{auth: &runtimeapi.AuthConfig{Username: username, Password: password}}
+}
+
+// NewIdentityTokenCredentialProvider builds an ImageCredentialProvider
+// backed by a static identity token, e.g. for registries that authenticate
+// pulls via OAuth2 bearer tokens rather than a username/password pair.
+func NewIdentityTokenCredentialProvider(token string) ImageCredentialProvider {
+	return &staticCredentialProvider{auth: &runtimeapi.AuthConfig{IdentityToken: token}}
+}
+
+func (p *staticCredentialProvider) Resolve(_ string) (*runtimeapi.AuthConfig, error) {
+	return p.auth, nil
+}
+
+// ImagePullAccountManager resolves per-registry-host credentials the way a
+// Kubernetes credential provider does: from a JSON config file pointed to by
+// CRI_TOOLS_IMAGE_CREDENTIALS (a map of registry host to AuthConfig), plus
+// CRI_TOOLS_REGISTRY_USERNAME / CRI_TOOLS_REGISTRY_PASSWORD for the single
+// registry named by CRI_TOOLS_REGISTRY_HOST (testPrivateRegistry by default).
+// For that host the environment credentials replace any entry from the file.
+type ImagePullAccountManager struct {
+	creds map[string]*runtimeapi.AuthConfig
+}
+
+// NewImagePullAccountManager builds an ImagePullAccountManager from the
+// environment described above.
+func NewImagePullAccountManager() (*ImagePullAccountManager, error) {
+	m := &ImagePullAccountManager{creds: map[string]*runtimeapi.AuthConfig{}}
+
+	if path := os.Getenv("CRI_TOOLS_IMAGE_CREDENTIALS"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image credentials file %q: %v", path, err)
+		}
+		if err := json.Unmarshal(data, &m.creds); err != nil {
+			return nil, fmt.Errorf("failed to parse image credentials file %q: %v", path, err)
+		}
+	}
+
+	if username, password := os.Getenv("CRI_TOOLS_REGISTRY_USERNAME"), os.Getenv("CRI_TOOLS_REGISTRY_PASSWORD"); username != "" || password != "" {
+		host := os.Getenv("CRI_TOOLS_REGISTRY_HOST")
+		if host == "" {
+			host = testPrivateRegistry
+		}
+		m.creds[host] = &runtimeapi.AuthConfig{Username: username, Password: password}
+	}
+
+	return m, nil
+}
+
+// Resolve implements ImageCredentialProvider.
+func (m *ImagePullAccountManager) Resolve(registryHost string) (*runtimeapi.AuthConfig, error) {
+	if auth, ok := m.creds[registryHost]; ok {
+		return auth, nil
+	}
+	return nil, nil
+}
+
+var _ = framework.KubeDescribe("Image Manager", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	getClient := imageClientFixture(f)
+
+	It("resolves static username/password and identity-token credentials [Conformance]", func() {
+		byUserPass := NewUserPassCredentialProvider("testuser", "testpass")
+		auth, err := byUserPass.Resolve(testPrivateRegistry)
+		framework.ExpectNoError(err, "failed to resolve username/password credentials: %v", err)
+		Expect(auth.Username).To(Equal("testuser"))
+		Expect(auth.Password).To(Equal("testpass"))
+
+		byToken := NewIdentityTokenCredentialProvider("test-identity-token")
+		auth, err = byToken.Resolve(testPrivateRegistry)
+		framework.ExpectNoError(err, "failed to resolve identity-token credentials: %v", err)
+		Expect(auth.IdentityToken).To(Equal("test-identity-token"))
+	})
+
+	Context("when the runtime supports authenticated pulls", func() {
+		var account *ImagePullAccountManager
+
+		BeforeEach(func() {
+			var err error
+			account, err = NewImagePullAccountManager()
+			framework.ExpectNoError(err, "failed to build image pull account manager: %v", err)
+		})
+
+		It("should pull a private image using resolved per-registry credentials [Conformance]", func() {
+			auth, err := account.Resolve(testPrivateRegistry)
+			framework.ExpectNoError(err, "failed to resolve credentials for %s: %v", testPrivateRegistry, err)
+			if auth == nil {
+				Skip(fmt.Sprintf("no credentials configured for %s; set CRI_TOOLS_REGISTRY_USERNAME/CRI_TOOLS_REGISTRY_PASSWORD or CRI_TOOLS_IMAGE_CREDENTIALS to run this test", testPrivateRegistry))
+			}
+
+			c := getClient()
+			pullImageWithAuth(c, testPrivateImageRef, auth)
+			defer removeImage(c, testPrivateImageRef)
+
+			images := listImageForImageName(c, testPrivateImageRef)
+			Expect(len(images)).To(Equal(1), "Should have one image in list")
+		})
+
+		It("should fail with a clean auth error when credentials are wrong [Conformance]", func() {
+			if !privateRegistryConfigured() {
+				Skip(fmt.Sprintf("no reachable registry configured; set CRI_TOOLS_REGISTRY_HOST (or CRI_TOOLS_IMAGE_CREDENTIALS) to a real registry to run this test, the %s placeholder does not resolve", testPrivateRegistry))
+			}
+
+			c := getClient()
+			wrongAuth := &runtimeapi.AuthConfig{Username: "wrong", Password: "wrong"}
+
+			By("Pull image with wrong credentials : " + testPrivateImageRef)
+			imageSpec := &runtimeapi.ImageSpec{Image: testPrivateImageRef}
+			_, err := c.PullImage(imageSpec, wrongAuth)
+			Expect(err).To(HaveOccurred(), "pulling with wrong credentials should return an error")
+			Expect(isAuthError(err)).To(BeTrue(), "pulling with wrong credentials should fail with a clean auth/unauthorized error, got: %v", err)
+		})
+
+		It("should still allow RemoveImage to succeed after an authenticated pull [Conformance]", func() {
+			auth, err := account.Resolve(testPrivateRegistry)
+			framework.ExpectNoError(err, "failed to resolve credentials for %s: %v", testPrivateRegistry, err)
+			if auth == nil {
+				Skip(fmt.Sprintf("no credentials configured for %s", testPrivateRegistry))
+			}
+
+			c := getClient()
+			pullImageWithAuth(c, testPrivateImageRef, auth)
+			testRemoveImage(c, testPrivateImageRef)
+		})
+	})
+})
+
+// privateRegistryConfigured reports whether the operator pointed the
+// authenticated-pull tests at an actual registry, as opposed to leaving
+// testPrivateRegistry at its unresolvable registry.example.com placeholder.
+func privateRegistryConfigured() bool {
+	return os.Getenv("CRI_TOOLS_REGISTRY_HOST") != "" || os.Getenv("CRI_TOOLS_IMAGE_CREDENTIALS") != ""
+}
+
+// isAuthError reports whether err looks like the runtime rejected a pull
+// for authentication/authorization reasons, as opposed to a network or DNS
+// failure that also happens to satisfy HaveOccurred().
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unauthenticated, codes.PermissionDenied:
+			return true
+		}
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, substr := range []string{"unauthorized", "unauthenticated", "authentication", " 401", " 403"} {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// pullImageWithAuth pulls imageName using the supplied AuthConfig, the
+// authenticated counterpart of pullPublicImage.
+func pullImageWithAuth(c imageServiceClient, imageName string, auth *runtimeapi.AuthConfig) {
+	By("Pull image with credentials : " + imageName)
+	imageSpec := &runtimeapi.ImageSpec{Image: imageName}
+	_, err := c.PullImage(imageSpec, auth)
+	framework.ExpectNoError(err, "failed to pull image %q with credentials: %v", imageName, err)
+}
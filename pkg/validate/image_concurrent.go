@@ -0,0 +1,150 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"sync"
+
+	"github.com/kubernetes-incubator/cri-tools/pkg/framework"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/api/v1alpha1/runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// concurrentPullWorkers is the number of goroutines the stress test below
+// runs in parallel against the same and different image refs.
+const concurrentPullWorkers = 8
+
+// concurrentPullResult is one worker's outcome, reported over a channel so
+// the Gomega assertions can all run back on the spec's own goroutine —
+// By() and Gomega matchers are not safe to call concurrently.
+type concurrentPullResult struct {
+	ref  string
+	size int64
+	err  error
+}
+
+var _ = framework.KubeDescribe("Image Manager", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	getClient := imageClientFixture(f)
+
+	It("should survive concurrent PullImage/RemoveImage/ImageStatus without leaking or duplicating images [Conformance]", func() {
+		c := getClient()
+
+		refs := []string{
+			"busybox:1-uclibc",
+			"busybox:1-musl",
+			"busybox:1-glibc",
+		}
+
+		By("Make sure none of the refs are already resident from another spec before measuring a baseline")
+		for _, ref := range refs {
+			removeImage(c, ref)
+		}
+
+		baseline := map[string]int64{}
+		for _, ref := range refs {
+			baseline[ref] = imageSize(c, ref)
+			Expect(baseline[ref]).To(Equal(int64(0)), "image %s should not be resident before the stress run starts", ref)
+		}
+
+		results := make(chan concurrentPullResult, concurrentPullWorkers)
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentPullWorkers; i++ {
+			ref := refs[i%len(refs)]
+
+			wg.Add(1)
+			go func(ref string) {
+				defer wg.Done()
+				defer GinkgoRecover()
+				results <- pullRemoveStatusOnce(c, ref)
+			}(ref)
+		}
+		wg.Wait()
+		close(results)
+
+		peak := map[string]int64{}
+		for result := range results {
+			Expect(result.err).NotTo(HaveOccurred(), "concurrent worker for %s should not error", result.ref)
+			if result.size > peak[result.ref] {
+				peak[result.ref] = result.size
+			}
+		}
+
+		By("Check that no image was leaked or duplicated by a concurrent worker")
+		images := listImage(c, &runtimeapi.ImageFilter{})
+		for _, ref := range refs {
+			for _, image := range images {
+				for _, tag := range image.RepoTags {
+					Expect(tag).NotTo(Equal(ref), "image %s should have been removed by a concurrent worker, found a leaked entry", ref)
+				}
+			}
+		}
+		Expect(images).To(BeEmpty(), "ListImages should report no images left behind by the concurrent workers")
+
+		By("Report per-image size deltas gathered from ImageStatus before/after the stress run")
+		for _, ref := range refs {
+			final := imageSize(c, ref)
+			framework.Logf("image %s: size delta while present = %d bytes (baseline %d, peak %d, final %d)",
+				ref, peak[ref]-baseline[ref], baseline[ref], peak[ref], final)
+			Expect(final).To(Equal(int64(0)), "image %s should be back to a zero size once the stress run has removed it", ref)
+		}
+	})
+})
+
+// pullRemoveStatusOnce pulls ref, reads its size via ImageStatus, and
+// removes it again, returning the outcome instead of asserting on it so it
+// can run safely from one of several concurrent goroutines. ImageStatus can
+// legitimately return (nil, nil) here if another worker's RemoveImage for
+// the same shared ref races ahead of this one — that race, not a bug in the
+// test, is exactly what this stress test is trying to surface.
+func pullRemoveStatusOnce(c imageServiceClient, ref string) concurrentPullResult {
+	imageSpec := &runtimeapi.ImageSpec{Image: ref}
+
+	if _, err := c.PullImage(imageSpec, nil); err != nil {
+		return concurrentPullResult{ref: ref, err: err}
+	}
+
+	var size int64
+	if status, err := c.ImageStatus(imageSpec); err != nil {
+		return concurrentPullResult{ref: ref, err: err}
+	} else if status != nil {
+		size = int64(status.Size_)
+	}
+
+	if err := c.RemoveImage(imageSpec); err != nil {
+		return concurrentPullResult{ref: ref, err: err}
+	}
+
+	return concurrentPullResult{ref: ref, size: size}
+}
+
+// imageSize returns the size ImageStatus reports for ref, or 0 if the image
+// does not exist. Only safe to call serially — it goes through the shared
+// By()/Gomega-asserting imageStatus-style helpers.
+func imageSize(c imageServiceClient, ref string) int64 {
+	By("Get image size for " + ref)
+	status, err := c.ImageStatus(&runtimeapi.ImageSpec{Image: ref})
+	framework.ExpectNoError(err, "failed to get image status: %v", err)
+	if status == nil {
+		return 0
+	}
+	return int64(status.Size_)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"github.com/kubernetes-incubator/cri-tools/pkg/framework"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = framework.KubeDescribe("Image Manager", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	getClient := imageClientFixture(f)
+
+	Context("when filtering the image list", func() {
+		filterTestImages := []string{
+			"busybox:1-uclibc",
+			"busybox:1-musl",
+			"busybox:1-glibc",
+		}
+
+		BeforeEach(func() {
+			pullImageList(getClient(), filterTestImages)
+		})
+
+		AfterEach(func() {
+			removeImageList(getClient(), filterTestImages)
+		})
+
+		It("should list only images matching a reference= filter [Conformance]", func() {
+			c := getClient()
+
+			By("List images filtered by reference : " + filterTestImages[0])
+			images := listImageForImageName(c, filterTestImages[0])
+			Expect(len(images)).To(Equal(1), "reference= filter should return exactly the image it names")
+
+			for _, image := range images {
+				matchesFilter := false
+				for _, tag := range image.RepoTags {
+					if tag == filterTestImages[0] {
+						matchesFilter = true
+					}
+					for _, other := range filterTestImages[1:] {
+						Expect(tag).NotTo(Equal(other), "reference= filter for %s should not also return %s", filterTestImages[0], other)
+					}
+				}
+				Expect(matchesFilter).To(BeTrue(), "image returned by the reference= filter should carry the filtered-for tag")
+			}
+		})
+
+		It("should list only images matching a label= filter [Conformance]", func() {
+			Skip("CRI ImageFilter does not yet carry a label field; registered here so the gap stays visible")
+		})
+
+		It("should list only images created before a given image (before= filter) [Conformance]", func() {
+			Skip("CRI ImageFilter does not yet carry a before field; registered here so the gap stays visible")
+		})
+
+		It("should list only images created since a given image (since= filter) [Conformance]", func() {
+			Skip("CRI ImageFilter does not yet carry a since field; registered here so the gap stays visible")
+		})
+
+		It("should list only the image matching an id= filter [Conformance]", func() {
+			Skip("CRI ImageFilter does not yet carry an id field; registered here so the gap stays visible")
+		})
+
+		It("should report an untagged image as dangling after its last tag is removed (dangling= filter) [Conformance]", func() {
+			Skip("CRI ImageFilter does not yet carry a dangling field; registered here so the gap stays visible")
+		})
+	})
+
+	Context("when pruning a dangling image", func() {
+		It("should report an image as dangling once its last tag is removed (RemoveImage-based prune) [Conformance]", func() {
+			Skip("CRI has no retag RPC, so a tag can't be pointed at an existing image and then removed without " +
+				"also removing the underlying image; and ImageFilter does not yet carry a dangling field to assert " +
+				"against. Registered here so the gap stays visible until the CRI surface grows one or both.")
+		})
+	})
+})
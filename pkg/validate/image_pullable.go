@@ -0,0 +1,69 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"github.com/kubernetes-incubator/cri-tools/pkg/framework"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/api/v1alpha1/runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = framework.KubeDescribe("Image Manager", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	getClient := imageClientFixture(f)
+
+	It("should report a pullable RepoDigest after pulling by tag [Conformance]", func() {
+		c := getClient()
+		assertImageIsPullable(c, testImageRef)
+	})
+
+	It("image status for a non-existent image should return (nil, nil) [Conformance]", func() {
+		c := getClient()
+
+		By("Get image status for a non-existent image")
+		imageSpec := &runtimeapi.ImageSpec{Image: testImageName + ":this-tag-does-not-exist"}
+		status, err := c.ImageStatus(imageSpec)
+		framework.ExpectNoError(err, "ImageStatus for a non-existent image should not return an error: %v", err)
+		Expect(status).To(BeNil(), "ImageStatus for a non-existent image should return a nil Image")
+	})
+})
+
+// assertImageIsPullable pulls ref by tag and checks that the returned Image
+// carries a RepoDigests entry that is itself a valid pull spec: removing the
+// tagged image and re-pulling by that digest resolves to the same image Id.
+// This mirrors the kubelet fix for docker-pullable:// handling
+// (kubernetes#34380), giving runtime authors a portable way to detect the
+// same class of bug.
+func assertImageIsPullable(c imageServiceClient, ref string) {
+	pullPublicImage(c, ref)
+
+	status := imageStatus(c, ref)
+	Expect(len(status.RepoDigests)).NotTo(Equal(0), "Image should have at least one RepoDigest after pulling %s", ref)
+	originalID := status.Id
+	digestRef := status.RepoDigests[0]
+
+	testRemoveImage(c, ref)
+
+	pullPublicImage(c, digestRef)
+	defer removeImage(c, digestRef)
+
+	digestStatus := imageStatus(c, digestRef)
+	Expect(digestStatus.Id).To(Equal(originalID), "Re-pulling by digest %s should resolve to the same image Id as the original tag %s", digestRef, ref)
+}